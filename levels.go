@@ -18,10 +18,15 @@ package badger
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"math"
 	"math/rand"
 	"os"
+	"runtime"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/coocood/badger/epoch"
@@ -45,14 +50,99 @@ type levelsController struct {
 
 	cstatus compactStatus
 
+	// seekCandidates holds tables whose allowed seeks have been exhausted by
+	// DB.Get walking past them into a deeper level, mirroring LevelDB's
+	// seek-compaction trigger. Protected by seekCandidatesMu.
+	seekCandidatesMu sync.Mutex
+	seekCandidates   []seekCompactCandidate
+
+	// seekBudgets tracks each live table's remaining allowed seeks, keyed by
+	// table ID: table.Table has no seek-budget field of its own, so this
+	// bookkeeping lives on the controller instead. Entries are created lazily
+	// on first miss and removed once their table is compacted away.
+	seekBudgets sync.Map // uint64 -> *int32
+
+	// compactPointer[level] is the largest key included in the most recent
+	// compaction of that level, used by fillTables to rotate its seed table
+	// seek across the whole level instead of always picking the hottest
+	// subrange. Disjoint sub-range compactions on the same level can run
+	// concurrently (see cstatus), so reads and writes both go through
+	// compactPointerMu rather than touching the slice directly.
+	compactPointerMu sync.Mutex
+	compactPointer   []y.Key
+
+	// running tracks in-flight compactions by level, so an urgent L0
+	// compaction can preempt a lower-priority one instead of waiting behind
+	// it. Protected by runningMu.
+	runningMu sync.Mutex
+	running   map[int]*compactionRun
+
+	// cstats[level] accumulates compaction statistics for that level, surfaced
+	// through DB.LevelsStats(). Per-compaction byte/key counts are also
+	// reported into cd.nextLevel.metrics (see compactBuildTables), which is
+	// the controller's existing Prometheus-facing path; cstats exists
+	// alongside it for the simple in-memory query DB.LevelsStats() answers.
+	cstats []*cStats
+
 	opt options.TableBuilderOptions
 }
 
+// cStats accumulates per-level compaction statistics: how much time
+// compactions into this level have spent running, how many bytes they've
+// read and written, and how long addLevel0Table has spent stalled waiting
+// for this level (only meaningful for level 0). Mirrors the columns LevelDB
+// reports via its "leveldb.stats" property.
+type cStats struct {
+	sync.Mutex
+	numCompactions int64
+	timeSpent      time.Duration
+	bytesRead      int64
+	bytesWritten   int64
+	stallTime      time.Duration
+}
+
+func (cs *cStats) recordCompaction(d time.Duration, bytesRead, bytesWritten int64) {
+	cs.Lock()
+	defer cs.Unlock()
+	cs.numCompactions++
+	cs.timeSpent += d
+	cs.bytesRead += bytesRead
+	cs.bytesWritten += bytesWritten
+}
+
+func (cs *cStats) recordStall(d time.Duration) {
+	cs.Lock()
+	defer cs.Unlock()
+	cs.stallTime += d
+}
+
+// seekCompactCandidate is a table that has exhausted its allowed seeks and
+// should be prioritized for compaction regardless of its level's size score.
+type seekCompactCandidate struct {
+	level int
+	table *table.Table
+}
+
 var (
 	// This is for getting timings between stalls.
 	lastUnstalled time.Time
 )
 
+// ErrCancelledCompaction is returned (from compactBuildTables, through
+// runSubCompactions and runCompactDef) when an in-flight compaction is
+// preempted before it finishes, e.g. because L0 became stalled and needs the
+// worker slot more urgently. The partial output produced so far is discarded.
+// doCompact treats it as a preemption and returns no error for it.
+var ErrCancelledCompaction = errors.New("badger: compaction cancelled")
+
+// compactionRun tracks an in-flight compaction so a more urgent pick -- most
+// notably a stalling L0 -- can preempt it instead of queueing behind it.
+type compactionRun struct {
+	level     int
+	startedAt time.Time
+	cancel    context.CancelFunc
+}
+
 // revertToManifest checks that all necessary table files exist and removes all table files not
 // referenced by the manifest.  idMap is a set of table file id's that were read from the directory
 // listing.
@@ -87,6 +177,12 @@ func newLevelsController(kv *DB, mf *Manifest, mgr *epoch.ResourceManager, opt o
 		resourceMgr: mgr,
 	}
 	s.cstatus.levels = make([]*levelCompactStatus, kv.opt.TableBuilderOptions.MaxLevels)
+	s.compactPointer = make([]y.Key, kv.opt.TableBuilderOptions.MaxLevels)
+	s.running = make(map[int]*compactionRun)
+	s.cstats = make([]*cStats, kv.opt.TableBuilderOptions.MaxLevels)
+	for i := range s.cstats {
+		s.cstats[i] = &cStats{}
+	}
 
 	for i := 0; i < kv.opt.TableBuilderOptions.MaxLevels; i++ {
 		s.levels[i] = newLevelHandler(kv, i)
@@ -191,6 +287,13 @@ func (lc *levelsController) runWorker(c *y.Closer, scorePriority bool) {
 
 	for {
 		guard := lc.resourceMgr.Acquire()
+		if lc.isL0Compactable() {
+			// L0 is urgent: preempt an in-flight lower-priority compaction so
+			// a worker slot frees up for it instead of sitting behind an
+			// unrelated Ln compaction, avoiding the STALL loop in
+			// addLevel0Table getting starved.
+			lc.preemptForL0()
+		}
 		prios := lc.pickCompactLevels()
 		if scorePriority {
 			sort.Slice(prios, func(i, j int) bool {
@@ -226,16 +329,95 @@ func (lc *levelsController) isL0Compactable() bool {
 	return lc.levels[0].numTables() >= lc.kv.opt.NumLevelZeroTables
 }
 
-// Returns true if the non-zero level may be compacted.  deltaSize provides the size of the tables
-// which are currently being compacted so that we treat them as already having started being
-// compacted (because they have been, yet their size is already counted in getTotalSize).
-func (l *levelHandler) isCompactable(deltaSize int64) bool {
-	return l.getTotalSize() >= l.maxTotalSize+deltaSize
+// minPreemptableRuntime is the grace period a non-L0 compaction gets before
+// it becomes eligible for preemption. Without it, a sustained write load that
+// keeps L0 near its compaction threshold would have every runWorker tick
+// (isL0Compactable fires as often as every ~300ms) re-preempt the same wide
+// Ln->Ln+1 compaction before it ever finishes, so it never makes progress.
+const minPreemptableRuntime = 5 * time.Second
+
+// preemptForL0 cancels an in-flight compaction on another level so a worker
+// can pick up an urgent L0 compaction right away instead of queuing behind
+// it. It's a no-op if L0 is already being compacted, nothing else is
+// running, or every other run is still within its minPreemptableRuntime
+// grace period.
+func (lc *levelsController) preemptForL0() {
+	lc.runningMu.Lock()
+	defer lc.runningMu.Unlock()
+	if _, ok := lc.running[0]; ok {
+		return
+	}
+	for level, run := range lc.running {
+		if time.Since(run.startedAt) < minPreemptableRuntime {
+			continue
+		}
+		log.Infof("Preempting in-flight compaction on level %d for urgent L0 compaction", level)
+		run.cancel()
+		return
+	}
+}
+
+// Returns true if the non-zero level may be compacted, and the target size it
+// was scored against. deltaSize provides the size of the tables which are
+// currently being compacted so that we treat them as already having started
+// being compacted (because they have been, yet their size is already counted
+// in getTotalSize). target overrides the level's static maxTotalSize when
+// positive, e.g. with a dynamicLevelTargets() result.
+func (l *levelHandler) isCompactable(deltaSize, target int64) (bool, int64) {
+	if target <= 0 {
+		target = l.maxTotalSize
+	}
+	return l.getTotalSize() >= target+deltaSize, target
+}
+
+// dynamicLevelTargets computes the target size of each level as
+// bottomLevelSize / multiplier^(maxLevel-k), counting only down from the
+// deepest level that actually holds data so upper levels stay empty (at the
+// L1 floor) until the DB grows enough to need them. This is the scheme
+// goleveldb/RocksDB/Pebble call "dynamic level bytes": it keeps write
+// amplification bounded on small databases, where the classic fixed
+// multiplier would otherwise size every level as if the DB were already
+// full. Returns nil if the bottom level is still empty, meaning the caller
+// should fall back to the static per-level sizes.
+func (lc *levelsController) dynamicLevelTargets() []int64 {
+	n := len(lc.levels)
+	if n < 2 {
+		return nil
+	}
+	bottomSize := lc.levels[n-1].getTotalSize()
+	if bottomSize == 0 {
+		return nil
+	}
+
+	multiplier := int64(lc.kv.opt.TableBuilderOptions.LevelSizeMultiplier)
+	if multiplier < 2 {
+		multiplier = 2
+	}
+
+	// The bottom level is intentionally left at 0 (meaning "use the static
+	// maxTotalSize") rather than targeted against its own live size: a
+	// level is always compactable against a target equal to its current
+	// size, so it would be picked on essentially every poll, and there's no
+	// level below it to assert a next-level index into.
+	targets := make([]int64, n)
+	size := bottomSize
+	for level := n - 2; level >= 1; level-- {
+		size /= multiplier
+		if size < lc.kv.opt.LevelOneSize {
+			size = lc.kv.opt.LevelOneSize
+		}
+		targets[level] = size
+	}
+	return targets
 }
 
 type compactionPriority struct {
 	level int
 	score float64
+	// seekTable, if set, is a specific table that exhausted its allowed seeks
+	// and should be used as the compaction seed instead of the usual
+	// max-ratio search in fillTables.
+	seekTable *table.Table
 }
 
 // pickCompactLevel determines which level to compact.
@@ -254,19 +436,43 @@ func (lc *levelsController) pickCompactLevels() (prios []compactionPriority) {
 	}
 
 	// now calcalute scores from level 1
+	var dynTargets []int64
+	if lc.kv.opt.DynamicLevelBytes {
+		dynTargets = lc.dynamicLevelTargets()
+	}
 	for levelNum := 1; levelNum < len(lc.levels); levelNum++ {
 		// Don't consider those tables that are already being compacted right now.
 		deltaSize := lc.cstatus.deltaSize(levelNum)
 
 		l := lc.levels[levelNum]
-		if l.isCompactable(deltaSize) {
+		var dynTarget int64
+		if dynTargets != nil {
+			dynTarget = dynTargets[levelNum]
+		}
+		if ok, target := l.isCompactable(deltaSize, dynTarget); ok {
 			pri := compactionPriority{
 				level: levelNum,
-				score: float64(l.getTotalSize()-deltaSize) / float64(l.maxTotalSize),
+				score: float64(l.getTotalSize()-deltaSize) / float64(target),
 			}
 			prios = append(prios, pri)
 		}
 	}
+	// Seek-compaction candidates are reported regardless of how their level
+	// scores on size, since a table that keeps getting seeked past is hurting
+	// read latency right now. Give them a score >= 1.0 so they're treated as
+	// compactable, but don't requeue one that's already being compacted.
+	lc.seekCandidatesMu.Lock()
+	remaining := lc.seekCandidates[:0]
+	for _, c := range lc.seekCandidates {
+		if lc.isCompacting(c.level, c.table) {
+			remaining = append(remaining, c)
+			continue
+		}
+		prios = append(prios, compactionPriority{level: c.level, score: 1.0, seekTable: c.table})
+	}
+	lc.seekCandidates = remaining
+	lc.seekCandidatesMu.Unlock()
+
 	// We used to sort compaction priorities based on the score. But, we
 	// decided to compact based on the level, not the priority. So, upper
 	// levels (level 0, level 1, etc) always get compacted first, before the
@@ -274,6 +480,83 @@ func (lc *levelsController) pickCompactLevels() (prios []compactionPriority) {
 	return prios
 }
 
+// compactPointerForLevel returns the rotating seed cursor for level, guarded
+// against concurrent compactions on disjoint sub-ranges of the same level.
+func (lc *levelsController) compactPointerForLevel(level int) y.Key {
+	lc.compactPointerMu.Lock()
+	defer lc.compactPointerMu.Unlock()
+	return lc.compactPointer[level]
+}
+
+// setCompactPointer advances the rotating seed cursor for level, guarded
+// against concurrent compactions on disjoint sub-ranges of the same level.
+func (lc *levelsController) setCompactPointer(level int, key y.Key) {
+	lc.compactPointerMu.Lock()
+	defer lc.compactPointerMu.Unlock()
+	lc.compactPointer[level] = key
+}
+
+// bytesPerSeek and minAllowedSeeks derive a table's initial seek budget from
+// its size, mirroring LevelDB's "allowed_seeks = file_size / 16KB" heuristic:
+// a seek miss costs about as much as reading bytesPerSeek bytes off disk, so
+// a table is only worth compacting once enough misses have walked past it to
+// add up to roughly the cost of rewriting it.
+const (
+	bytesPerSeek    = 16 * 1024
+	minAllowedSeeks = 100
+)
+
+// seekBudget returns tbl's remaining-allowed-seeks counter, creating it from
+// the table's size the first time tbl is seen.
+func (lc *levelsController) seekBudget(tbl *table.Table) *int32 {
+	if v, ok := lc.seekBudgets.Load(tbl.ID()); ok {
+		return v.(*int32)
+	}
+	budget := int32(tbl.Size() / bytesPerSeek)
+	if budget < minAllowedSeeks {
+		budget = minAllowedSeeks
+	}
+	v, _ := lc.seekBudgets.LoadOrStore(tbl.ID(), &budget)
+	return v.(*int32)
+}
+
+// recordSeekMiss charges tbl for a failed seek -- a lookup that had to walk
+// past it into a deeper level to find the key. Once its allowed seeks are
+// exhausted, tbl is queued as a seek-compaction candidate for level.
+func (lc *levelsController) recordSeekMiss(level int, tbl *table.Table) {
+	if atomic.AddInt32(lc.seekBudget(tbl), -1) > 0 {
+		return
+	}
+	lc.seekCandidatesMu.Lock()
+	defer lc.seekCandidatesMu.Unlock()
+	for _, c := range lc.seekCandidates {
+		if c.table == tbl {
+			return
+		}
+	}
+	lc.seekCandidates = append(lc.seekCandidates, seekCompactCandidate{level: level, table: tbl})
+}
+
+// chargeSeekMiss records a seek miss against the first table at each level
+// above foundLevel (levels 0 through foundLevel-1) whose key range covers
+// key, since those are the tables the
+// lookup had to walk past before finding the value at foundLevel.
+func (lc *levelsController) chargeSeekMiss(key y.Key, foundLevel int) {
+	for level := 0; level < foundLevel; level++ {
+		l := lc.levels[level]
+		l.RLock()
+		left, right := l.overlappingTables(levelHandlerRLocked{}, keyRange{left: key, right: key})
+		var tbl *table.Table
+		if right > left {
+			tbl = l.tables[left]
+		}
+		l.RUnlock()
+		if tbl != nil {
+			lc.recordSeekMiss(level, tbl)
+		}
+	}
+}
+
 func (lc *levelsController) hasOverlapTable(cd *compactDef) bool {
 	kr := getKeyRange(cd.top)
 	for i := cd.nextLevel.level + 1; i < len(lc.levels); i++ {
@@ -288,6 +571,30 @@ func (lc *levelsController) hasOverlapTable(cd *compactDef) bool {
 	return false
 }
 
+// topTablesHaveNoLowerOverlap re-confirms, under nextLevel's own lock, that
+// none of cd.top overlaps a table currently in nextLevel or any level below
+// it. fillTables already established len(cd.bot) == 0 for the same range
+// while holding thisLevel/nextLevel's RLocks, but this re-check guards the
+// trivial-move fast path against nextLevel having changed between then and
+// now.
+func (lc *levelsController) topTablesHaveNoLowerOverlap(cd *compactDef) bool {
+	if len(cd.top) == 0 {
+		// Everything fillTables picked was already split out by
+		// splitTrivialMoves into cd.moves; there's nothing left in cd.top to
+		// check for overlap, so the "no overlap" condition holds vacuously.
+		return true
+	}
+	kr := getKeyRange(cd.top)
+	cd.nextLevel.RLock()
+	left, right := cd.nextLevel.overlappingTables(levelHandlerRLocked{}, kr)
+	overlaps := right > left
+	cd.nextLevel.RUnlock()
+	if overlaps {
+		return false
+	}
+	return !lc.hasOverlapTable(cd)
+}
+
 type DiscardStats struct {
 	numSkips     int64
 	skippedBytes int64
@@ -359,11 +666,32 @@ func overSkipTables(key y.Key, skippedTables []*table.Table) (newSkippedTables [
 }
 
 // compactBuildTables merge topTables and botTables to form a list of new tables.
-func (lc *levelsController) compactBuildTables(level int, cd *compactDef,
-	limiter *rate.Limiter, splitHints []y.Key) (newTables []*table.Table, err error) {
+// If ctx is cancelled while the merge is in progress (e.g. this compaction was
+// preempted for a more urgent one), it stops early, discards the tables
+// built so far, and returns ErrCancelledCompaction.
+//
+// If lo/hi are non-empty, the merge is restricted to keys in [lo, hi); this
+// is how runSubCompactions runs several of these concurrently over disjoint
+// slices of the same (cd.top, cd.bot) overlap.
+func (lc *levelsController) compactBuildTables(ctx context.Context, level int, cd *compactDef,
+	limiter *rate.Limiter, splitHints []y.Key, lo, hi y.Key) (newTables []*table.Table, err error) {
 	topTables := cd.top
 	botTables := cd.bot
 
+	// When building a single sub-compaction's output, narrow the input tables
+	// down to the ones that can actually contain [lo, hi) first: otherwise
+	// every sub-compaction worker would build a merge iterator over cd.top and
+	// cd.bot in full and linearly Next() past everything before its own
+	// sub-range, turning what should be O(total keys) work across all workers
+	// into O(total keys * numWorkers). Level 0 top tables overlap each other,
+	// so they are not narrowed; the lo/hi checks below still bound the merge.
+	if !lo.IsEmpty() || !hi.IsEmpty() {
+		if level > 0 {
+			topTables = tablesInBounds(topTables, lo, hi)
+		}
+		botTables = tablesInBounds(botTables, lo, hi)
+	}
+
 	hasOverlap := lc.hasOverlapTable(cd)
 	log.Infof("Key range overlaps with lower levels: %v", hasOverlap)
 
@@ -384,6 +712,11 @@ func (lc *levelsController) compactBuildTables(level int, cd *compactDef,
 	it := table.NewMergeIterator(iters, false)
 
 	it.Rewind()
+	if !lo.IsEmpty() {
+		for it.Valid() && it.Key().Compare(lo) < 0 {
+			it.Next()
+		}
+	}
 
 	// Pick up the currently pending transactions' min readTs, so we can discard versions below this
 	// readTs. We should never discard any versions starting from above this timestamp, because that
@@ -401,7 +734,18 @@ func (lc *levelsController) compactBuildTables(level int, cd *compactDef,
 	var lastKey, skipKey y.Key
 	var builder *table.Builder
 	var bytesRead, bytesWrite, numRead, numWrite int
-	for it.Valid() {
+	for it.Valid() && (hi.IsEmpty() || it.Key().Compare(hi) < 0) {
+		select {
+		case <-ctx.Done():
+			for _, tbl := range newTables {
+				tbl.Delete()
+			}
+			newTables = nil
+			err = ErrCancelledCompaction
+			return
+		default:
+		}
+
 		fileID := lc.reserveFileID()
 		filename := table.NewFilename(fileID, lc.kv.opt.Dir)
 		var fd *os.File
@@ -416,7 +760,7 @@ func (lc *levelsController) compactBuildTables(level int, cd *compactDef,
 		}
 		lastKey.Reset()
 		guard := searchGuard(it.Key().UserKey, guards)
-		for ; it.Valid(); it.Next() {
+		for ; it.Valid() && (hi.IsEmpty() || it.Key().Compare(hi) < 0); it.Next() {
 			numRead++
 			vs := it.Value()
 			key := it.Key()
@@ -531,6 +875,116 @@ func (lc *levelsController) compactBuildTables(level int, cd *compactDef,
 	return
 }
 
+// maxSubCompactionWorkers bounds how many parallel sub-compaction workers a
+// single compaction step fans out to, regardless of how wide its key range
+// or how many CPUs are available.
+const maxSubCompactionWorkers = 4
+
+// pickSubCompactionBounds splits cd's (top, bot) overlap into n disjoint
+// sub-ranges using the input tables' own smallest keys as split points (a
+// cheap stand-in for per-block boundaries), mirroring Pebble's sub-compaction
+// design. It returns the n-1 interior boundary keys in sorted order; pairing
+// them with the unbounded ends gives n ranges. Each boundary sorts before
+// every version of its user key, so all versions of a key fall in the same
+// sub-range. Returns nil if there aren't enough distinct tables to make
+// splitting worthwhile.
+func pickSubCompactionBounds(cd *compactDef, n int) []y.Key {
+	if n <= 1 {
+		return nil
+	}
+	var starts []y.Key
+	for _, t := range cd.top {
+		starts = append(starts, t.Smallest())
+	}
+	for _, t := range cd.bot {
+		starts = append(starts, t.Smallest())
+	}
+	if len(starts) < n {
+		return nil
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Compare(starts[j]) < 0 })
+	step := len(starts) / n
+	splits := make([]y.Key, 0, n-1)
+	for i := 1; i < n; i++ {
+		splits = append(splits, y.Key{UserKey: starts[i*step].UserKey, Version: math.MaxUint64})
+	}
+	return splits
+}
+
+// tablesInBounds narrows tables (sorted, non-overlapping, as found within a
+// single compactDef side) down to the ones that can contain a key in
+// [lo, hi), using the tables' own key ranges rather than scanning their
+// contents. An empty lo or hi means that side of the range is unbounded.
+// Used to scope a sub-compaction worker's iterators to its own sub-range
+// instead of the whole level.
+func tablesInBounds(tables []*table.Table, lo, hi y.Key) []*table.Table {
+	if len(tables) == 0 {
+		return tables
+	}
+	smallest, biggest := lo, hi
+	if smallest.IsEmpty() {
+		smallest = tables[0].Smallest()
+	}
+	if biggest.IsEmpty() {
+		biggest = tables[len(tables)-1].Biggest()
+	}
+	left, right := getTablesInRange(tables, smallest, biggest)
+	return tables[left:right]
+}
+
+// runSubCompactions runs compactBuildTables over cd's (top, bot) overlap,
+// splitting the key range into several disjoint sub-ranges and executing them
+// concurrently when the overlap is wide enough to make that worthwhile. Each
+// worker shares limiter, so total write throughput is still bounded the same
+// way a single-threaded compaction would be. Results are merged into one
+// sorted table list; if any worker fails, all tables produced by the others
+// are deleted before the error is returned.
+func (lc *levelsController) runSubCompactions(ctx context.Context, level int, cd *compactDef, limiter *rate.Limiter) ([]*table.Table, error) {
+	// Size the sub-worker pool off idle CPU budget, not off NumCompactors:
+	// NumCompactors already bounds how many top-level compaction goroutines
+	// run concurrently (see startCompact), so reusing it here as a per-
+	// compaction multiplier would let actual concurrency reach
+	// NumCompactors * maxSubCompactionWorkers.
+	numWorkers := runtime.GOMAXPROCS(0)
+	if numWorkers > maxSubCompactionWorkers {
+		numWorkers = maxSubCompactionWorkers
+	}
+	splits := pickSubCompactionBounds(cd, numWorkers)
+	if len(splits) == 0 {
+		return lc.compactBuildTables(ctx, level, cd, limiter, nil, y.Key{}, y.Key{})
+	}
+
+	bounds := append([]y.Key{{}}, splits...)
+	bounds = append(bounds, y.Key{})
+
+	var wg sync.WaitGroup
+	results := make([][]*table.Table, len(bounds)-1)
+	errs := make([]error, len(bounds)-1)
+	for i := 0; i < len(bounds)-1; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = lc.compactBuildTables(ctx, level, cd, limiter, nil, bounds[i], bounds[i+1])
+		}(i)
+	}
+	wg.Wait()
+
+	var newTables []*table.Table
+	for i, subErr := range errs {
+		if subErr != nil {
+			for _, tbls := range results {
+				for _, t := range tbls {
+					t.Delete()
+				}
+			}
+			return nil, subErr
+		}
+		newTables = append(newTables, results[i]...)
+	}
+	sortTables(newTables)
+	return newTables, nil
+}
+
 func buildChangeSet(cd *compactDef, newTables []*table.Table) protos.ManifestChangeSet {
 	changes := []*protos.ManifestChange{}
 	for _, table := range newTables {
@@ -555,9 +1009,19 @@ type compactDef struct {
 
 	skippedTbls []*table.Table
 
+	// moves holds top tables that were split out by splitTrivialMoves because
+	// their key range doesn't overlap anything in bot or any deeper level.
+	// These are promoted to nextLevel by a manifest-only change instead of
+	// being rewritten through compactBuildTables.
+	moves []*table.Table
+
 	thisRange keyRange
 	nextRange keyRange
 
+	// seekTable, if set, is a seek-compaction candidate that fillTables should
+	// prefer as its seed over the usual max-ratio search.
+	seekTable *table.Table
+
 	topSize     int64
 	topLeftIdx  int
 	topRightIdx int
@@ -689,27 +1153,79 @@ func (lc *levelsController) fillTables(cd *compactDef) bool {
 	next := make([]*table.Table, len(cd.nextLevel.tables))
 	copy(next, cd.nextLevel.tables)
 
-	// First pick one table has max topSize/bottomSize ratio.
+	// First pick one table has max topSize/bottomSize ratio. The search
+	// rotates across the level using compactPointer as a round-robin cursor,
+	// so a single hot subrange can't monopolize every compaction slot: we
+	// restrict the first pass to tables at or past the cursor, and only fall
+	// back to the full level if nothing qualifies there.
 	var candidateRatio float64
-	for i, t := range this {
-		if lc.isCompacting(cd.thisLevel.level, t) {
-			continue
-		}
-		left, right := getTablesInRange(next, t.Smallest(), t.Biggest())
-		if lc.isCompacting(cd.nextLevel.level, next[left:right]...) {
-			continue
-		}
-		botSize := sumTableSize(next[left:right])
-		ratio := calcRatio(t.Size(), botSize)
-		if ratio > candidateRatio {
-			candidateRatio = ratio
+
+	if cd.seekTable != nil {
+		// A seek-compaction candidate was queued for this level: use it as the
+		// seed directly instead of searching for the max-ratio table below, so
+		// a hot-but-buried key range gets compacted even though it never trips
+		// size-based scoring.
+		for i, t := range this {
+			if t != cd.seekTable {
+				continue
+			}
+			if lc.isCompacting(cd.thisLevel.level, t) {
+				break
+			}
+			left, right := getTablesInRange(next, t.Smallest(), t.Biggest())
+			if lc.isCompacting(cd.nextLevel.level, next[left:right]...) {
+				break
+			}
 			cd.topLeftIdx = i
 			cd.topRightIdx = i + 1
 			cd.top = this[cd.topLeftIdx:cd.topRightIdx:cd.topRightIdx]
 			cd.topSize = t.Size()
 			cd.botLeftIdx = left
 			cd.botRightIdx = right
-			cd.botSize = botSize
+			cd.botSize = sumTableSize(next[left:right])
+			// Seed candidateRatio from the seek table's own ratio so the
+			// expand loops below only fold in neighbors that are at least as
+			// good a ratio as the seed, instead of comparing against the zero
+			// value and accreting unrelated cold tables up to
+			// maxCompactionExpandSize.
+			candidateRatio = calcRatio(cd.topSize, cd.botSize)
+			break
+		}
+	}
+
+	pointer := lc.compactPointerForLevel(cd.thisLevel.level)
+	considerSeed := func(restrictToCursor bool) bool {
+		found := false
+		for i, t := range this {
+			if restrictToCursor && !pointer.IsEmpty() && t.Smallest().Compare(pointer) < 0 {
+				continue
+			}
+			if lc.isCompacting(cd.thisLevel.level, t) {
+				continue
+			}
+			left, right := getTablesInRange(next, t.Smallest(), t.Biggest())
+			if lc.isCompacting(cd.nextLevel.level, next[left:right]...) {
+				continue
+			}
+			botSize := sumTableSize(next[left:right])
+			ratio := calcRatio(t.Size(), botSize)
+			if ratio > candidateRatio {
+				candidateRatio = ratio
+				cd.topLeftIdx = i
+				cd.topRightIdx = i + 1
+				cd.top = this[cd.topLeftIdx:cd.topRightIdx:cd.topRightIdx]
+				cd.topSize = t.Size()
+				cd.botLeftIdx = left
+				cd.botRightIdx = right
+				cd.botSize = botSize
+				found = true
+			}
+		}
+		return found
+	}
+	if len(cd.top) == 0 {
+		if !considerSeed(true) {
+			considerSeed(false)
 		}
 	}
 	if len(cd.top) == 0 {
@@ -784,9 +1300,53 @@ func (lc *levelsController) fillTables(cd *compactDef) bool {
 	for _, t := range cd.skippedTbls {
 		cd.botSize -= t.Size()
 	}
+	lc.splitTrivialMoves(cd)
 	return lc.cstatus.compareAndAdd(thisAndNextLevelRLocked{}, *cd)
 }
 
+// splitTrivialMoves pulls top tables that don't overlap anything in cd.bot or
+// any level below nextLevel out of cd.top and into cd.moves. Such tables can
+// be promoted to nextLevel by a manifest-only change, avoiding the cost of
+// re-opening and rewriting them through compactBuildTables -- the classic
+// LevelDB/RocksDB "trivial move" optimization.
+func (lc *levelsController) splitTrivialMoves(cd *compactDef) {
+	if len(cd.top) == 0 {
+		return
+	}
+	kept := cd.top[:0:0]
+	for _, t := range cd.top {
+		if lc.isTrivialMove(cd, t) {
+			cd.moves = append(cd.moves, t)
+		} else {
+			kept = append(kept, t)
+		}
+	}
+	cd.top = kept
+}
+
+// isTrivialMove reports whether t can be promoted to cd.nextLevel without
+// rewriting it: its range must miss every table already selected into cd.bot,
+// and every level below nextLevel.
+func (lc *levelsController) isTrivialMove(cd *compactDef, t *table.Table) bool {
+	for _, b := range cd.bot {
+		if t.HasOverlap(b.Smallest(), b.Biggest(), true) {
+			return false
+		}
+	}
+	kr := keyRange{left: t.Smallest(), right: t.Biggest()}
+	for i := cd.nextLevel.level + 1; i < len(lc.levels); i++ {
+		lh := lc.levels[i]
+		lh.RLock()
+		left, right := lh.overlappingTables(levelHandlerRLocked{}, kr)
+		overlap := right > left
+		lh.RUnlock()
+		if overlap {
+			return false
+		}
+	}
+	return true
+}
+
 func sumTableSize(tables []*table.Table) int64 {
 	var size int64
 	for _, t := range tables {
@@ -815,7 +1375,7 @@ func (lc *levelsController) isCompacting(level int, tables ...*table.Table) bool
 	return lc.cstatus.overlapsWith(level, kr)
 }
 
-func (lc *levelsController) runCompactDef(l int, cd *compactDef, limiter *rate.Limiter, guard *epoch.Guard) error {
+func (lc *levelsController) runCompactDef(ctx context.Context, l int, cd *compactDef, limiter *rate.Limiter, guard *epoch.Guard) error {
 	timeStart := time.Now()
 
 	thisLevel := cd.thisLevel
@@ -833,8 +1393,15 @@ func (lc *levelsController) runCompactDef(l int, cd *compactDef, limiter *rate.L
 		}
 	}()
 
-	if l > 0 && len(cd.bot) == 0 && len(cd.skippedTbls) == 0 {
-		// skip level 0, since it may has many table overlap with each other
+	if l > 0 && len(cd.bot) == 0 && lc.topTablesHaveNoLowerOverlap(cd) {
+		// Every table in cd.top has no overlap with nextLevel (cd.bot is
+		// empty) or anything below it, including cd.skippedTbls: those were
+		// already excluded from cd.bot precisely because they don't overlap
+		// cd.top, so leaving them untouched is safe. Promote the whole
+		// cd.top by a manifest-only move instead of rewriting it through
+		// compactBuildTables -- the classic LevelDB/RocksDB "trivial move"
+		// optimization, which eliminates write amplification almost
+		// entirely for sequential-insert Ln->Ln+1 steps.
 		newTables = cd.top
 		changeSet = protos.ManifestChangeSet{}
 		for _, t := range newTables {
@@ -843,13 +1410,19 @@ func (lc *levelsController) runCompactDef(l int, cd *compactDef, limiter *rate.L
 		topMove = true
 	} else {
 		var err error
-		newTables, err = lc.compactBuildTables(l, cd, limiter, nil)
+		newTables, err = lc.runSubCompactions(ctx, l, cd, limiter)
 		if err != nil {
 			return err
 		}
 		changeSet = buildChangeSet(cd, newTables)
 	}
 
+	// Tables split out by splitTrivialMoves are promoted by manifest change
+	// alone; they never go through compactBuildTables.
+	for _, t := range cd.moves {
+		changeSet.Changes = append(changeSet.Changes, newMoveDownChange(t.ID(), cd.nextLevel.level))
+	}
+
 	// We write to the manifest _before_ we delete files (and after we created files)
 	if err := lc.kv.manifest.addChanges(changeSet.Changes, nil); err != nil {
 		return err
@@ -857,14 +1430,40 @@ func (lc *levelsController) runCompactDef(l int, cd *compactDef, limiter *rate.L
 
 	// See comment earlier in this function about the ordering of these ops, and the order in which
 	// we access levels when reading.
-	nextLevel.replaceTables(newTables, cd, guard)
+	nextLevel.replaceTables(append(newTables, cd.moves...), cd, guard)
 	thisLevel.deleteTables(cd.top, guard, topMove)
+	if len(cd.moves) > 0 {
+		// Moved tables are removed from thisLevel without a physical file
+		// delete, same as the whole-level topMove fast path above.
+		thisLevel.deleteTables(cd.moves, guard, true)
+	}
+
+	// cd.bot is always rewritten away; cd.top is too unless this was a
+	// trivial move, in which case the same table ID lives on at nextLevel and
+	// should keep its seek budget rather than start over.
+	for _, t := range cd.bot {
+		lc.seekBudgets.Delete(t.ID())
+	}
+	if !topMove {
+		for _, t := range cd.top {
+			lc.seekBudgets.Delete(t.ID())
+		}
+	}
+
+	if l > 0 {
+		// Advance the rotating seed cursor past the range we just compacted,
+		// so the next pick on this level prefers an untouched subrange.
+		lc.setCompactPointer(l, cd.thisRange.right)
+	}
 
 	// Note: For level 0, while doCompact is running, it is possible that new tables are added.
 	// However, the tables are added only to the end, so it is ok to just delete the first table.
 
+	elapsed := time.Since(timeStart)
+	lc.cstats[cd.nextLevel.level].recordCompaction(elapsed, cd.topSize+cd.botSize, sumTableSize(newTables))
+
 	log.Infof("LOG Compact %s, del %d tables, add %d tables, took %v",
-		cd, len(cd.top)+len(cd.bot), len(newTables), time.Since(timeStart))
+		cd, len(cd.top)+len(cd.bot), len(newTables), elapsed)
 	return nil
 }
 
@@ -876,6 +1475,7 @@ func (lc *levelsController) doCompact(p compactionPriority, guard *epoch.Guard)
 	cd := &compactDef{
 		thisLevel: lc.levels[l],
 		nextLevel: lc.levels[l+1],
+		seekTable: p.seekTable,
 	}
 
 	log.Infof("Got compaction priority: %+v", p)
@@ -895,8 +1495,23 @@ func (lc *levelsController) doCompact(p compactionPriority, guard *epoch.Guard)
 	}
 	defer lc.cstatus.delete(cd) // Remove the ranges from compaction status.
 
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.runningMu.Lock()
+	lc.running[l] = &compactionRun{level: l, startedAt: time.Now(), cancel: cancel}
+	lc.runningMu.Unlock()
+	defer func() {
+		lc.runningMu.Lock()
+		delete(lc.running, l)
+		lc.runningMu.Unlock()
+		cancel()
+	}()
+
 	log.Infof("Running compaction: %s", cd)
-	if err := lc.runCompactDef(l, cd, lc.kv.limiter, guard); err != nil {
+	if err := lc.runCompactDef(ctx, l, cd, lc.kv.limiter, guard); err != nil {
+		if err == ErrCancelledCompaction {
+			log.Infof("Compaction on level %d preempted: %+v", l, cd)
+			return false, nil
+		}
 		// This compaction couldn't be done successfully.
 		log.Infof("\tLOG Compact FAILED with error: %+v: %+v", err, cd)
 		return false, err
@@ -906,7 +1521,174 @@ func (lc *levelsController) doCompact(p compactionPriority, guard *epoch.Guard)
 	return true, nil
 }
 
+// compactionHandle lets a caller of compactRange block until the manual
+// compaction it started has finished.
+type compactionHandle struct {
+	done chan error
+}
+
+// Wait blocks until the manual compaction finishes, returning any error it
+// hit.
+func (h *compactionHandle) Wait() error {
+	return <-h.done
+}
+
+// CompactRange runs one manual compaction on each level that has a table
+// overlapping [start, end], from the deepest level up, and blocks until they
+// finish. Each of those compactions chooses its input tables the way the
+// background compactions do (all of L0; on deeper levels a seed table picked
+// by size ratio, see fillTables), so it is not limited to the tables in
+// [start, end], and tables in the range can remain uncompacted after a call.
+func (db *DB) CompactRange(start, end y.Key) error {
+	return db.lc.compactRange(start, end).Wait()
+}
+
+// compactRange starts, in the background, one manual compaction per level
+// whose tables overlap [start, end] (see compactRangeOnLevel), walking the
+// levels bottom-up so a deep level is squared away before the level feeding
+// it is considered. It coordinates with cstatus so it doesn't fight the
+// background pickers over the same tables, and skips levels that have no
+// overlap with the range.
+func (lc *levelsController) compactRange(start, end y.Key) *compactionHandle {
+	h := &compactionHandle{done: make(chan error, 1)}
+	go func() {
+		kr := keyRange{left: start, right: end}
+		guard := lc.resourceMgr.Acquire()
+		defer guard.Done()
+		// Walk bottom-up (deepest source level first). Data that a later,
+		// upper-level step of this call pushes down is not compacted further
+		// by the same call.
+		for level := len(lc.levels) - 2; level >= 0; level-- {
+			if err := lc.compactRangeOnLevel(level, kr, guard); err != nil {
+				h.done <- err
+				return
+			}
+		}
+		h.done <- nil
+	}()
+	return h
+}
+
+// compactRangeOnLevel runs a single manual compaction of level into level+1
+// when level has a table overlapping kr. The input tables are chosen by
+// fillTablesL0 or fillTables, not by kr, so they need not overlap kr. It's a
+// no-op if kr doesn't overlap level, if a background picker is already
+// compacting that range, or if no tables can be picked.
+func (lc *levelsController) compactRangeOnLevel(level int, kr keyRange, guard *epoch.Guard) error {
+	l := lc.levels[level]
+	l.RLock()
+	left, right := l.overlappingTables(levelHandlerRLocked{}, kr)
+	overlaps := right > left
+	l.RUnlock()
+	if !overlaps {
+		return nil
+	}
+	if lc.cstatus.overlapsWith(level, kr) {
+		// Let the in-flight background compaction finish rather than
+		// fighting it for the same tables.
+		return nil
+	}
+
+	cd := &compactDef{
+		thisLevel: lc.levels[level],
+		nextLevel: lc.levels[level+1],
+	}
+	var filled bool
+	if level == 0 {
+		filled = lc.fillTablesL0(cd)
+	} else {
+		filled = lc.fillTables(cd)
+	}
+	if !filled {
+		return nil
+	}
+	defer lc.cstatus.delete(cd)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	return lc.runCompactDef(ctx, level, cd, lc.kv.limiter, guard)
+}
+
+// maxMemFlushLevel bounds how deep pickFlushLevel will place a freshly
+// flushed memtable. Levels deeper than this are reserved for background
+// compaction so a burst of flushes can't push tables all the way to the
+// bottom of the LSM.
+const maxMemFlushLevel = 2
+
+// pickFlushLevel returns the deepest level a memtable covering [smallest,
+// biggest] can be flushed to directly, bypassing L0 (and the L0->L1
+// compaction it would otherwise force). The range must overlap no table in
+// L0 or in any level from 1 down to the returned one, no in-progress
+// compaction on those levels, and less than LevelOneSize/8 bytes of the level
+// below the returned one. It falls back to level 0 when no such level
+// exists, or when L0 is close to stalling and needs to keep draining.
+func (lc *levelsController) pickFlushLevel(smallest, biggest y.Key) int {
+	if lc.levels[0].numTables() >= lc.kv.opt.NumLevelZeroTablesStall-1 {
+		// L0 is close to stalling; always drain through it so the stall logic
+		// in addLevel0Table doesn't get starved by tables that skipped L0.
+		return 0
+	}
+
+	kr := keyRange{left: smallest, right: biggest}
+
+	// L0 holds older data that reads check first, so a memtable overlapping
+	// it must stay above it.
+	l0 := lc.levels[0]
+	l0.RLock()
+	for _, t := range l0.tables {
+		if t.Smallest().Compare(biggest) <= 0 && smallest.Compare(t.Biggest()) <= 0 {
+			l0.RUnlock()
+			return 0
+		}
+	}
+	l0.RUnlock()
+
+	target := 0
+	for level := 1; level < len(lc.levels) && level <= maxMemFlushLevel; level++ {
+		l := lc.levels[level]
+		l.RLock()
+		left, right := l.overlappingTables(levelHandlerRLocked{}, kr)
+		overlaps := right > left
+		l.RUnlock()
+		if overlaps {
+			break
+		}
+		if lc.cstatus.overlapsWith(level, kr) {
+			break
+		}
+		if level+1 < len(lc.levels) {
+			next := lc.levels[level+1]
+			next.RLock()
+			nLeft, nRight := next.overlappingTables(levelHandlerRLocked{}, kr)
+			overlapSize := sumTableSize(next.tables[nLeft:nRight])
+			next.RUnlock()
+			if overlapSize >= lc.kv.opt.LevelOneSize/8 {
+				break
+			}
+		}
+		target = level
+	}
+	return target
+}
+
+// addLevel0Table installs a freshly flushed memtable's table. Where
+// pickFlushLevel finds the memtable's key range disjoint from L0 and from the
+// levels above some deeper level, the table is placed directly at the deepest such level,
+// bypassing L0 (and the L0->L1 compaction that would otherwise force); this
+// substantially reduces write amplification for bulk-load and sequential-key
+// workloads. Otherwise it falls through to L0 as before.
 func (lc *levelsController) addLevel0Table(t *table.Table, head *protos.HeadInfo) error {
+	if level := lc.pickFlushLevel(t.Smallest(), t.Biggest()); level > 0 {
+		err := lc.kv.manifest.addChanges([]*protos.ManifestChange{
+			newCreateChange(t.ID(), level, t.CompressionType()),
+		}, head)
+		if err != nil {
+			return err
+		}
+		lc.levels[level].addTable(t)
+		return nil
+	}
+
 	// We update the manifest _before_ the table becomes part of a levelHandler, because at that
 	// point it could get used in some compaction.  This ensures the manifest file gets updated in
 	// the proper order. (That means this update happens before that of some compaction which
@@ -947,8 +1729,10 @@ func (lc *levelsController) addLevel0Table(t *table.Table, head *protos.HeadInfo
 				i = 0
 			}
 		}
+		stallDur := time.Since(timeStart)
+		lc.cstats[0].recordStall(stallDur)
 		log.Infof("UNSTALLED UNSTALLED UNSTALLED UNSTALLED UNSTALLED UNSTALLED: %v",
-			time.Since(timeStart))
+			stallDur)
 		lastUnstalled = time.Now()
 	}
 
@@ -969,9 +1753,12 @@ func (s *levelsController) get(key y.Key, keyHash uint64) y.ValueStruct {
 	// number.)
 	start := time.Now()
 	defer s.kv.metrics.LSMGetDuration.Observe(time.Since(start).Seconds())
-	for _, h := range s.levels {
+	for i, h := range s.levels {
 		vs := h.get(key, keyHash) // Calls h.RLock() and h.RUnlock().
 		if vs.Valid() {
+			if i > 0 {
+				s.chargeSeekMiss(key, i)
+			}
 			return vs
 		}
 	}
@@ -1033,3 +1820,44 @@ func (lc *levelsController) getTableInfo() (result []TableInfo) {
 	})
 	return
 }
+
+// LevelStats reports compaction statistics for a single level, modeled after
+// the columns LevelDB reports via its "leveldb.stats" property.
+type LevelStats struct {
+	Level     int
+	NumTables int
+	SizeMB    float64
+	TimeSec   float64
+	ReadMB    float64
+	WriteMB   float64
+}
+
+// LevelsStats returns per-level compaction statistics for every level of the
+// LSM tree, letting callers inspect write amplification per level. Time
+// addLevel0Table spends stalled is recorded in cStats but not reported here.
+//
+// This is a pull-based, in-process Go accessor only -- nothing here is
+// registered with Prometheus (kv.metrics isn't touched). An operator who
+// wants these numbers graphed needs to poll this and export the result
+// themselves, e.g. on a timer.
+func (db *DB) LevelsStats() []LevelStats {
+	lc := db.lc
+	stats := make([]LevelStats, len(lc.levels))
+	for i, l := range lc.levels {
+		cs := lc.cstats[i]
+		cs.Lock()
+		timeSec := cs.timeSpent.Seconds()
+		readMB := float64(cs.bytesRead) / (1 << 20)
+		writeMB := float64(cs.bytesWritten) / (1 << 20)
+		cs.Unlock()
+		stats[i] = LevelStats{
+			Level:     i,
+			NumTables: l.numTables(),
+			SizeMB:    float64(l.getTotalSize()) / (1 << 20),
+			TimeSec:   timeSec,
+			ReadMB:    readMB,
+			WriteMB:   writeMB,
+		}
+	}
+	return stats
+}